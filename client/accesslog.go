@@ -0,0 +1,113 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/filter"
+	"trpc.group/trpc-go/trpc-go/log"
+)
+
+// AccessLogConfig configures AccessLogFilter.
+type AccessLogConfig struct {
+	// SamplingRate is the fraction of RPCs, in [0,1], that get logged.
+	// <= 0 disables logging, >= 1 logs every RPC.
+	SamplingRate float64
+	// RedactKey reports whether a metadata key's value must be scrubbed
+	// before it is included in accessLogEntry.Metadata. Nil leaves
+	// accessLogEntry.Metadata out of the log line entirely.
+	RedactKey func(key string) bool
+}
+
+// WithAccessLog enables AccessLogFilter for this backend.
+func WithAccessLog(config AccessLogConfig) Option {
+	return func(o *Options) { o.AccessLog = &config }
+}
+
+// accessLogEntry is the one-JSON-line-per-RPC shape AccessLogFilter emits.
+type accessLogEntry struct {
+	Caller        string            `json:"caller"`
+	Callee        string            `json:"callee"`
+	Method        string            `json:"method"`
+	Node          string            `json:"node"`
+	CostMs        float64           `json:"cost_ms"`
+	RetryCount    int               `json:"retry_count"`
+	Compressor    string            `json:"compressor,omitempty"`
+	Serialization int               `json:"serialization"`
+	ErrorCode     int               `json:"error_code"`
+	ErrorMsg      string            `json:"error_msg,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+}
+
+// AccessLogFilter emits one structured JSON log line per sampled RPC,
+// recording enough to reconstruct what backend, node, codec and outcome a
+// call used without needing to read rpcz spans.
+func AccessLogFilter(ctx context.Context, req interface{}, rsp interface{}, next filter.ClientHandleFunc) error {
+	opts := OptionsFromContext(ctx)
+	config := opts.AccessLog
+	if config == nil || config.SamplingRate <= 0 {
+		return next(ctx, req, rsp)
+	}
+	sampled := config.SamplingRate >= 1 || rand.Float64() < config.SamplingRate
+	if !sampled {
+		return next(ctx, req, rsp)
+	}
+
+	msg := codec.Message(ctx)
+	ctx = contextWithRetryAttempts(ctx)
+	begin := time.Now()
+	err := next(ctx, req, rsp)
+	cost := time.Since(begin)
+
+	entry := accessLogEntry{
+		Caller:        msg.CallerServiceName(),
+		Callee:        msg.CalleeServiceName(),
+		Method:        msg.ClientRPCName(),
+		Node:          opts.Node.String(),
+		CostMs:        float64(cost) / float64(time.Millisecond),
+		RetryCount:    RetryAttempts(ctx),
+		Compressor:    opts.CompressorName,
+		Serialization: msg.SerializationType(),
+	}
+	if e, ok := err.(*errs.Error); ok {
+		entry.ErrorCode = int(e.Code)
+		entry.ErrorMsg = e.Msg
+	}
+	if config.RedactKey != nil {
+		entry.Metadata = redactMetaData(msg.ClientMetaData(), config.RedactKey)
+	}
+
+	if buf, jsonErr := json.Marshal(entry); jsonErr == nil {
+		log.InfoContextf(ctx, "%s", buf)
+	}
+	return err
+}
+
+func redactMetaData(md codec.MetaData, redact func(string) bool) map[string]string {
+	out := make(map[string]string, len(md))
+	for k, v := range md {
+		if redact(k) {
+			out[k] = "***"
+			continue
+		}
+		out[k] = string(v)
+	}
+	return out
+}
@@ -37,6 +37,8 @@ import (
 type Client interface {
 	// Invoke performs a unary RPC.
 	Invoke(ctx context.Context, reqBody interface{}, rspBody interface{}, opt ...Option) error
+	// NewStream begins a server-streaming or bidi-streaming RPC, see StreamDesc.
+	NewStream(ctx context.Context, desc *StreamDesc, method string, opt ...Option) (ClientStream, error)
 }
 
 // DefaultClient is the default global client.
@@ -90,10 +92,12 @@ func (c *client) Invoke(ctx context.Context, reqBody interface{}, rspBody interf
 		defer cancel()
 	}
 	if deadline, ok := ctx.Deadline(); ok {
-		msg.WithRequestTimeout(deadline.Sub(time.Now()))
+		remaining := deadline.Sub(time.Now())
+		msg.WithRequestTimeout(remaining)
+		propagateDeadline(msg, opts, remaining)
 	}
 	if ok && (opts.Timeout <= 0 || time.Until(fullLinkDeadline) < opts.Timeout) {
-		opts.fixTimeout = mayConvert2FullLinkTimeout
+		opts.fixTimeout = fixCallerTimeout
 	}
 
 	// Start filter chain processing.
@@ -170,6 +174,9 @@ func (c *client) updateMsg(msg codec.Msg, opts *Options) {
 	if icodec.IsValidCompressType(opts.CompressType) && opts.CompressType != codec.CompressTypeNoop {
 		msg.WithCompressType(opts.CompressType)
 	}
+	if icodec.IsValidCompressorName(opts.CompressorName) {
+		advertiseAcceptEncoding(msg)
+	}
 
 	// Set client req head if needed.
 	if opts.ReqHead != nil {
@@ -213,14 +220,39 @@ func (c *client) fixFilters(opts *Options) filter.ClientChain {
 	if opts.DisableFilter || len(opts.Filters) == 0 {
 		// All filters but selector filter are disabled.
 		opts.FilterNames = append(opts.FilterNames, DefaultSelectorFilterName) // NOTES: 这里可以看到，selectorFilter 是必须的且是自动注入的
-		return filter.ClientChain{selectorFilter}
+		return append(retryFilterChain(opts), selectorFilter)
 	}
 	if !opts.selectorFilterPosFixed {
-		// Selector filter pos is not fixed, append it to the filter chain.
+		// Selector filter pos is not fixed: splice the retry/hedging filter in
+		// directly ahead of selectorFilter, after any user filters (e.g.
+		// AccessLogFilter/OtelFilter) already in opts.Filters, so those user
+		// filters see exactly one call per RPC — including the final retry
+		// count — instead of being re-run on every attempt.
+		opts.Filters = append(opts.Filters, retryFilterChain(opts)...)
 		opts.Filters = append(opts.Filters, selectorFilter)
 		opts.FilterNames = append(opts.FilterNames, DefaultSelectorFilterName)
+		return opts.Filters
+	}
+	// selectorFilterPosFixed: the caller already placed selectorFilter at a
+	// specific index in opts.Filters, so there's no safe splice point without
+	// tracking that index. Fall back to wrapping the whole chain; retries
+	// still re-run selectorFilter, just without the same one-call-per-RPC
+	// guarantee for filters positioned around it.
+	return append(retryFilterChain(opts), opts.Filters...)
+}
+
+// retryFilterChain returns the single-element chain for whichever of
+// RetryPolicy/HedgingPolicy is configured (hedging wins if both are set),
+// or nil if neither is.
+func retryFilterChain(opts *Options) filter.ClientChain {
+	switch {
+	case opts.HedgingPolicy != nil:
+		return filter.ClientChain{hedgingFilter}
+	case opts.RetryPolicy != nil:
+		return filter.ClientChain{retryFilter}
+	default:
+		return nil
 	}
-	return opts.Filters
 }
 
 // callFunc is the function that calls the backend service with
@@ -250,6 +282,14 @@ func callFunc(ctx context.Context, reqBody interface{}, rspBody interface{}) (er
 	if opts.EnableMultiplexed {
 		opts.CallOptions = append(opts.CallOptions, transport.WithMsg(msg), transport.WithMultiplexed(true))
 	}
+	if opts.Pool != nil {
+		// opts.Transport is opts.Pool itself (see WithConnPool): RoundTrip needs
+		// the selected node's network/address to find/dial the right pooled
+		// conn, since it has no other way to see msg.
+		if addr := msg.RemoteAddr(); addr != nil {
+			opts.CallOptions = append(opts.CallOptions, transport.WithTarget(addr.Network(), addr.String()))
+		}
+	}
 	// NOTES: 发送主逻辑,获取返回包 是请求传输过程
 	rspBuf, err := opts.Transport.RoundTrip(ctx, reqBuf, opts.CallOptions...)
 	if err != nil {
@@ -316,13 +356,23 @@ func processResponseBuf(
 	// Decompress.
 	span := rpcz.SpanFromContext(ctx)
 	_, end := span.NewChild("Decompress")
-	compressType := msg.CompressType()
-	if icodec.IsValidCompressType(opts.CurrentCompressType) { // NOTES: 解压缩
-		compressType = opts.CurrentCompressType
-	}
 	var err error
-	if icodec.IsValidCompressType(compressType) && compressType != codec.CompressTypeNoop {
-		rspBodyBuf, err = codec.Decompress(compressType, rspBodyBuf)
+	name := responseCompressorName(msg)
+	if name == "" && icodec.IsValidCompressorName(opts.CompressorName) {
+		name = opts.CompressorName
+	}
+	if name != "" {
+		// The response may be compressed with a different codec than the
+		// request used, so prefer the negotiated name over opts.CompressorName.
+		rspBodyBuf, err = decompressWithRegistry(name, rspBodyBuf)
+	} else {
+		compressType := msg.CompressType()
+		if icodec.IsValidCompressType(opts.CurrentCompressType) { // NOTES: 解压缩
+			compressType = opts.CurrentCompressType
+		}
+		if icodec.IsValidCompressType(compressType) && compressType != codec.CompressTypeNoop {
+			rspBodyBuf, err = codec.Decompress(compressType, rspBodyBuf)
+		}
 	}
 	end.End()
 	if err != nil {
@@ -371,12 +421,16 @@ func serializeAndCompress(ctx context.Context, msg codec.Msg, reqBody interface{
 
 	// Compress.
 	_, end = span.NewChild("Compress")
-	compressType := msg.CompressType() // 获取压缩类型
-	if icodec.IsValidCompressType(opts.CurrentCompressType) {
-		compressType = opts.CurrentCompressType // 注: 可以看到非法时使用默认值, 未报错
-	}
-	if icodec.IsValidCompressType(compressType) && compressType != codec.CompressTypeNoop {
-		reqBodyBuf, err = codec.Compress(compressType, reqBodyBuf)
+	if icodec.IsValidCompressorName(opts.CompressorName) {
+		reqBodyBuf, err = compressWithRegistry(opts.CompressorName, reqBodyBuf)
+	} else {
+		compressType := msg.CompressType() // 获取压缩类型
+		if icodec.IsValidCompressType(opts.CurrentCompressType) {
+			compressType = opts.CurrentCompressType // 注: 可以看到非法时使用默认值, 未报错
+		}
+		if icodec.IsValidCompressType(compressType) && compressType != codec.CompressTypeNoop {
+			reqBodyBuf, err = codec.Compress(compressType, reqBodyBuf)
+		}
 	}
 	end.End()
 	if err != nil {
@@ -0,0 +1,97 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package client
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/errs"
+)
+
+// acceptEncodingMetaKey is the metadata key the client uses to advertise every
+// Compressor it can decode, mirroring grpc-encoding semantics: the server may
+// answer with any of them regardless of what the request was compressed with.
+const acceptEncodingMetaKey = "trpc-accept-encoding"
+
+// contentEncodingMetaKey carries the name of the Compressor the server
+// actually used for the response, read back before calling codec.Decompress
+// equivalents so the client tolerates request/response codec mismatches.
+const contentEncodingMetaKey = "trpc-content-encoding"
+
+// WithCompressor selects a streaming codec.Compressor by name, registered via
+// codec.RegisterCompressor. It takes precedence over the int CompressType set
+// by WithCompressType/WithCurrentCompressType.
+func WithCompressor(name string) Option {
+	return func(o *Options) { o.CompressorName = name }
+}
+
+// advertiseAcceptEncoding sets acceptEncodingMetaKey to every registered
+// Compressor name so the server can pick whichever it prefers for the response.
+func advertiseAcceptEncoding(msg codec.Msg) {
+	md := msg.ClientMetaData()
+	if md == nil {
+		md = codec.MetaData{}
+	}
+	md[acceptEncodingMetaKey] = []byte(strings.Join(codec.RegisteredCompressorNames(), ","))
+	msg.WithClientMetaData(md)
+}
+
+// compressWithRegistry runs buf through the named Compressor, used instead of
+// the legacy int CompressType codec path when Options.CompressorName is set.
+func compressWithRegistry(name string, buf []byte) ([]byte, error) {
+	c, ok := codec.GetCompressor(name)
+	if !ok {
+		return nil, errs.NewFrameError(errs.RetClientEncodeFail, "client: unknown compressor "+name)
+	}
+	var out bytes.Buffer
+	w, err := c.NewWriter(&out)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(buf); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// decompressWithRegistry decompresses buf using name, the negotiated encoding
+// read out of the response's contentEncodingMetaKey, which may differ from
+// the Compressor the request was sent with.
+func decompressWithRegistry(name string, buf []byte) ([]byte, error) {
+	c, ok := codec.GetCompressor(name)
+	if !ok {
+		return nil, errs.NewFrameError(errs.RetClientDecodeFail, "client: unknown compressor "+name)
+	}
+	r, err := c.NewReader(bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// responseCompressorName returns the Compressor name the server reported for
+// the response via contentEncodingMetaKey, or "" if none was set.
+func responseCompressorName(msg codec.Msg) string {
+	md := msg.ClientMetaData()
+	if md == nil {
+		return ""
+	}
+	return string(md[contentEncodingMetaKey])
+}
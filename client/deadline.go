@@ -0,0 +1,75 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package client
+
+import (
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/errs"
+)
+
+// WithDisableDeadlinePropagation opts this call out of writing codec.TimeoutMetaKey
+// onto the wire, for backends that mishandle an unexpected metadata key.
+func WithDisableDeadlinePropagation() Option {
+	return func(o *Options) { o.DisableDeadlinePropagation = true }
+}
+
+// propagateDeadline writes the caller's remaining full-link deadline into
+// msg's client metadata so the callee can install it as its own incoming
+// context deadline, the client-side half of full-link timeout propagation.
+func propagateDeadline(msg codec.Msg, opts *Options, remaining time.Duration) {
+	if opts.DisableDeadlinePropagation || remaining <= 0 {
+		return
+	}
+	md := msg.ClientMetaData()
+	if md == nil {
+		md = codec.MetaData{}
+	}
+	md[codec.TimeoutMetaKey] = []byte(codec.EncodeTimeout(remaining))
+	msg.WithClientMetaData(md)
+}
+
+// callerDeadlineExceeded wraps a RetClientTimeout error that fired because
+// the caller's own full-link deadline (the one propagateDeadline sent
+// upstream) ran out, as opposed to this hop's local opts.Timeout — the two
+// look identical as a bare *errs.Error, so callers that need to tell a slow
+// downstream apart from an impatient caller check for this wrapper instead.
+type callerDeadlineExceeded struct{ err error }
+
+func (e *callerDeadlineExceeded) Error() string { return e.err.Error() }
+func (e *callerDeadlineExceeded) Unwrap() error { return e.err }
+
+// IsCallerDeadlineExceeded reports whether err is a RetClientTimeout caused
+// by the caller's full-link deadline elapsing, rather than this hop's own
+// opts.Timeout.
+func IsCallerDeadlineExceeded(err error) bool {
+	_, ok := err.(*callerDeadlineExceeded)
+	return ok
+}
+
+// fixCallerTimeout wraps mayConvert2FullLinkTimeout's result so that a
+// RetClientTimeout surfaced after the full-link deadline conversion is
+// distinguishable, via IsCallerDeadlineExceeded, from one this hop produced
+// against its own local opts.Timeout.
+func fixCallerTimeout(err error) error {
+	err = mayConvert2FullLinkTimeout(err)
+	if err == nil {
+		return nil
+	}
+	if e, ok := err.(*errs.Error); ok && e.Code == errs.RetClientTimeout {
+		return &callerDeadlineExceeded{err: e}
+	}
+	return err
+}
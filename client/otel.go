@@ -0,0 +1,124 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package client
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/filter"
+	"trpc.group/trpc-go/trpc-go/rpcz"
+)
+
+// OtelFilterName is the name OtelFilter is registered under when wired
+// through WithFilter/filter.Register, for FilterNames/rpcz attribution.
+const OtelFilterName = "otel"
+
+var otelTracer = otel.Tracer("trpc.group/trpc-go/trpc-go/client")
+
+// metadataCarrier adapts codec.MetaData to propagation.TextMapCarrier so
+// traceparent/tracestate ride along in the same transparently-transmitted
+// metadata map used for everything else (see Options.MetaData).
+type metadataCarrier codec.MetaData
+
+func (c metadataCarrier) Get(key string) string { return string(c[key]) }
+func (c metadataCarrier) Set(key, value string) { c[key] = []byte(value) }
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// OtelFilter bridges the rpcz.Span children already recorded inside callFunc
+// (Marshal, Compress, EncodeProtocolHead, DecodeProtocolHead, Decompress,
+// Unmarshal) onto an OpenTelemetry span following the RPC semantic
+// conventions, and propagates the trace context to the callee via metadata.
+func OtelFilter(ctx context.Context, req interface{}, rsp interface{}, next filter.ClientHandleFunc) error {
+	msg := codec.Message(ctx)
+	rpczSpan := rpcz.SpanFromContext(ctx)
+
+	spanCtx, span := otelTracer.Start(ctx, msg.ClientRPCName(), trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("rpc.system", "trpc"),
+		attribute.String("rpc.service", msg.CalleeServiceName()),
+		attribute.String("rpc.method", msg.ClientRPCName()),
+	)
+	if host, port, ok := splitHostPort(msg.RemoteAddr()); ok {
+		span.SetAttributes(attribute.String("net.peer.name", host), attribute.Int("net.peer.port", port))
+	}
+
+	md := msg.ClientMetaData()
+	if md == nil {
+		md = codec.MetaData{}
+	}
+	otel.GetTextMapPropagator().Inject(spanCtx, metadataCarrier(md))
+	msg.WithClientMetaData(md)
+
+	err := next(spanCtx, req, rsp)
+
+	bridgeRpczChildren(spanCtx, rpczSpan)
+
+	if e, ok := err.(*errs.Error); ok {
+		span.SetAttributes(attribute.Int("rpc.trpc.status_code", int(e.Code)))
+		span.SetStatus(codes.Error, e.Msg)
+	} else if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetStatus(codes.Ok, "")
+	}
+	return err
+}
+
+// bridgeRpczChildren re-emits each of rpczSpan's children (the
+// Marshal/Compress/EncodeProtocolHead/.../Unmarshal stages callFunc already
+// records on it) as its own OTel child span under ctx's current span,
+// carrying over the child's name and start/end time, so a trace backend
+// shows the same per-stage breakdown rpcz already tracks internally.
+func bridgeRpczChildren(ctx context.Context, rpczSpan *rpcz.Span) {
+	if rpczSpan == nil {
+		return
+	}
+	for _, child := range rpczSpan.Children() {
+		_, childSpan := otelTracer.Start(ctx, child.Name(), trace.WithTimestamp(child.StartTime()))
+		childSpan.End(trace.WithTimestamp(child.EndTime()))
+	}
+}
+
+func splitHostPort(addr net.Addr) (string, int, bool) {
+	if addr == nil {
+		return "", 0, false
+	}
+	host, portStr, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return "", 0, false
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return "", 0, false
+	}
+	return host, port, true
+}
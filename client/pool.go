@@ -0,0 +1,49 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package client
+
+import (
+	"sync"
+
+	"trpc.group/trpc-go/trpc-go/transport"
+)
+
+// connPools caches one *transport.Pool per name so that repeated
+// WithConnPool(name, ...) applications against the same backend share its
+// warmed-up connections instead of each call opening its own empty Pool.
+var connPools sync.Map // map[string]*transport.Pool
+
+// WithConnPool enables transport.Pool-backed connection multiplexing for
+// this backend, an alternative to WithMultiplexed that also exposes
+// channelz-style introspection at /debug/trpc/channelz. It replaces
+// opts.Transport with the Pool itself — Pool implements both the one-shot
+// ClientTransport contract (RoundTrip) and ClientStreamTransport (NewStream)
+// on top of Pool.Get/pooledConn, so both Invoke and NewStream actually carry
+// traffic over multiplexed pooled connections instead of the Option having
+// no effect on the wire.
+//
+// name must be a stable identifier for the backend this Option targets
+// (e.g. its service name), shared across every call site that wants to
+// multiplex onto the same pooled connections: WithConnPool constructs a
+// brand-new, empty Pool the first time name is seen and reuses it on every
+// later call, so a per-call or per-Option-application name would silently
+// discard all previously-warmed connections and defeat pooling.
+func WithConnPool(name string, config transport.PoolConfig) Option {
+	pool, _ := connPools.LoadOrStore(name, transport.NewPool(config))
+	p := pool.(*transport.Pool)
+	return func(o *Options) {
+		o.Pool = p
+		o.Transport = p
+	}
+}
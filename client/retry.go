@@ -0,0 +1,288 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package client
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/filter"
+)
+
+// RetryPolicy configures the built-in retry filter. An attempt is retried
+// only if the previous one failed with one of RetryableCodes (or a framework
+// connect/timeout/net error, which is retryable regardless of RetryableCodes).
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// <= 1 disables retry.
+	MaxAttempts int
+	// InitialBackoff is the backoff before the second attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the backoff computed for any attempt.
+	MaxBackoff time.Duration
+	// Multiplier grows the backoff window between attempts.
+	Multiplier float64
+	// Jitter, when true, picks the backoff uniformly out of
+	// [InitialBackoff, prev*Multiplier] instead of using prev*Multiplier directly.
+	Jitter bool
+	// RetryableCodes lists errs.Code values that make an attempt eligible for retry.
+	RetryableCodes []int
+	// PerAttemptTimeout, if set, bounds a single attempt independently of the
+	// overall fullLinkDeadline; the remaining full-link budget still applies.
+	PerAttemptTimeout time.Duration
+}
+
+// HedgingPolicy configures the built-in hedging filter: it fires additional
+// attempts before the previous one fails, trading extra load for tail latency.
+// RetryPolicy and HedgingPolicy are mutually exclusive; WithHedgingPolicy wins
+// if both are set.
+type HedgingPolicy struct {
+	// MaxAttempts is the maximum number of concurrent hedged attempts.
+	MaxAttempts int
+	// HedgingDelay is the stagger between launching consecutive attempts.
+	HedgingDelay time.Duration
+	// NonFatalCodes lists errs.Code values that do not cancel the remaining
+	// in-flight attempts, allowing them a chance to still succeed.
+	NonFatalCodes []int
+}
+
+// WithRetryPolicy enables the retry filter, inserted ahead of selectorFilter
+// so that every attempt re-selects a node via opts.Selector.Select. It sits
+// right before selectorFilter unless selectorFilter's position in the chain
+// is fixed, in which case it runs at the head of the whole chain.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(o *Options) { o.RetryPolicy = &p }
+}
+
+// WithHedgingPolicy enables the hedging filter.
+func WithHedgingPolicy(p HedgingPolicy) Option {
+	return func(o *Options) { o.HedgingPolicy = &p }
+}
+
+// retryFilter retries the remaining filter chain (including selectorFilter)
+// up to opts.RetryPolicy.MaxAttempts times, cloning opts per attempt since
+// selectorFilter mutates msg/opts as seen through IsOptionsImmutable.
+func retryFilter(ctx context.Context, req interface{}, rsp interface{}, next filter.ClientHandleFunc) error {
+	opts := OptionsFromContext(ctx)
+	policy := opts.RetryPolicy
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return next(ctx, req, rsp)
+	}
+
+	ctx = contextWithImmutableOptions(ctx, opts)
+	deadline, hasDeadline := ctx.Deadline()
+
+	var err error
+	backoff := policy.InitialBackoff
+	for attempt := 0; attempt < policy.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			// RetryAttempts counts attempts beyond the first, matching what
+			// access logs mean by "retry count"; the initial attempt isn't a retry.
+			incrRetryAttempts(ctx)
+		}
+		attemptCtx := ctx
+		if policy.PerAttemptTimeout > 0 {
+			timeout := policy.PerAttemptTimeout
+			if hasDeadline {
+				if remaining := time.Until(deadline); remaining < timeout {
+					timeout = remaining
+				}
+			}
+			var cancel context.CancelFunc
+			attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		err = next(attemptCtx, req, rsp)
+		if err == nil || !isRetryable(err, policy.RetryableCodes) {
+			return err
+		}
+		if attempt == policy.MaxAttempts-1 {
+			return err
+		}
+		if hasDeadline && !time.Now().Add(backoff).Before(deadline) {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff = nextBackoff(backoff, policy)
+	}
+	return err
+}
+
+// nextBackoff implements gRPC's decorrelated-jitter connection backoff:
+// delay = min(MaxBackoff, random_between(InitialBackoff, prev*Multiplier)).
+func nextBackoff(prev time.Duration, policy *RetryPolicy) time.Duration {
+	upper := time.Duration(float64(prev) * policy.Multiplier)
+	if upper < policy.InitialBackoff {
+		upper = policy.InitialBackoff
+	}
+	next := upper
+	if policy.Jitter && upper > policy.InitialBackoff {
+		next = policy.InitialBackoff + time.Duration(rand.Int63n(int64(upper-policy.InitialBackoff)))
+	}
+	if policy.MaxBackoff > 0 && next > policy.MaxBackoff {
+		next = policy.MaxBackoff
+	}
+	return next
+}
+
+// hedgingFilter fans out up to policy.MaxAttempts concurrent calls staggered
+// by HedgingDelay, returning as soon as one succeeds (or one fails fatally)
+// and cancelling the remaining in-flight attempts.
+func hedgingFilter(ctx context.Context, req interface{}, rsp interface{}, next filter.ClientHandleFunc) error {
+	opts := OptionsFromContext(ctx)
+	policy := opts.HedgingPolicy
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return next(ctx, req, rsp)
+	}
+
+	ctx = contextWithImmutableOptions(ctx, opts)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		err error
+		rsp interface{}
+	}
+	results := make(chan result, policy.MaxAttempts)
+	for i := 0; i < policy.MaxAttempts; i++ {
+		// Each attempt unmarshals into its own clone of rsp: writing N
+		// concurrent attempts into the caller's shared rsp would race, and a
+		// losing attempt still running after hedgingFilter returns could keep
+		// mutating rsp after the caller has already started reading it.
+		attemptRsp := cloneRsp(rsp)
+		attempt := i
+		time.AfterFunc(time.Duration(i)*policy.HedgingDelay, func() {
+			if attempt > 0 {
+				// Mirror retryFilter: RetryAttempts counts attempts beyond the
+				// first, so access logs reflect hedging the same way they do retries.
+				incrRetryAttempts(ctx)
+			}
+			results <- result{err: next(ctx, req, attemptRsp), rsp: attemptRsp}
+		})
+	}
+
+	var lastErr error
+	for i := 0; i < policy.MaxAttempts; i++ {
+		select {
+		case r := <-results:
+			if r.err == nil {
+				copyRsp(rsp, r.rsp)
+				return nil
+			}
+			lastErr = r.err
+			if !isNonFatal(r.err, policy.NonFatalCodes) {
+				return r.err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return lastErr
+}
+
+// cloneRsp allocates a fresh zero value of rsp's underlying type so
+// concurrent hedged attempts each unmarshal into their own copy instead of
+// racing on the caller's rsp. rsp must be a pointer, as every generated
+// client stub's rspBody is; non-pointer values are returned as-is since they
+// can't be usefully cloned or written back into.
+func cloneRsp(rsp interface{}) interface{} {
+	rv := reflect.ValueOf(rsp)
+	if rv.Kind() != reflect.Ptr {
+		return rsp
+	}
+	return reflect.New(rv.Type().Elem()).Interface()
+}
+
+// copyRsp copies the winning hedged attempt's response (src) into the
+// caller's original rsp (dst), the one and only write to dst, performed
+// after that attempt has already fully completed.
+func copyRsp(dst, src interface{}) {
+	if dst == src {
+		return
+	}
+	dv, sv := reflect.ValueOf(dst), reflect.ValueOf(src)
+	if dv.Kind() == reflect.Ptr && sv.Kind() == reflect.Ptr {
+		dv.Elem().Set(sv.Elem())
+	}
+}
+
+// isRetryable reports whether err is eligible for another attempt: framework
+// connect/timeout/net errors are always retryable, matching selectorFilter's
+// existing special-casing; anything else must be listed in codes.
+func isRetryable(err error, codes []int) bool {
+	e, ok := err.(*errs.Error)
+	if !ok {
+		return false
+	}
+	if e.Type == errs.ErrorTypeFramework &&
+		(e.Code == errs.RetClientConnectFail || e.Code == errs.RetClientTimeout || e.Code == errs.RetClientNetErr) {
+		return true
+	}
+	return containsCode(codes, int(e.Code))
+}
+
+// isNonFatal reports whether err should let other in-flight hedged attempts
+// keep racing rather than aborting the whole call immediately.
+func isNonFatal(err error, codes []int) bool {
+	e, ok := err.(*errs.Error)
+	if !ok {
+		return false
+	}
+	return containsCode(codes, int(e.Code))
+}
+
+func containsCode(codes []int, code int) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// retryAttemptsKey stores a *int counter in ctx so filters outside the retry
+// loop (e.g. AccessLogFilter) can report how many retries an RPC took.
+type retryAttemptsKey struct{}
+
+// contextWithRetryAttempts attaches a fresh counter, to be read back later
+// with RetryAttempts once the whole filter chain (including retryFilter) has run.
+func contextWithRetryAttempts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryAttemptsKey{}, new(int))
+}
+
+func incrRetryAttempts(ctx context.Context) {
+	if n, ok := ctx.Value(retryAttemptsKey{}).(*int); ok {
+		*n++
+	}
+}
+
+// RetryAttempts returns how many attempts beyond the first retryFilter or
+// hedgingFilter has made so far for the RPC in ctx, or 0 if ctx was never
+// set up via contextWithRetryAttempts
+// (e.g. AccessLogFilter runs outside a retry-enabled call).
+func RetryAttempts(ctx context.Context) int {
+	if n, ok := ctx.Value(retryAttemptsKey{}).(*int); ok {
+		return *n
+	}
+	return 0
+}
@@ -0,0 +1,95 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNextBackoff(t *testing.T) {
+	policy := &RetryPolicy{
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     100 * time.Millisecond,
+		Multiplier:     2,
+	}
+	prev := policy.InitialBackoff
+	for i := 0; i < 10; i++ {
+		next := nextBackoff(prev, policy)
+		if next < policy.InitialBackoff {
+			t.Fatalf("nextBackoff(%v) = %v, want >= InitialBackoff %v", prev, next, policy.InitialBackoff)
+		}
+		if next > policy.MaxBackoff {
+			t.Fatalf("nextBackoff(%v) = %v, want <= MaxBackoff %v", prev, next, policy.MaxBackoff)
+		}
+		prev = next
+	}
+}
+
+func TestContainsCode(t *testing.T) {
+	codes := []int{100, 200, 300}
+	if !containsCode(codes, 200) {
+		t.Fatalf("containsCode(%v, 200) = false, want true", codes)
+	}
+	if containsCode(codes, 400) {
+		t.Fatalf("containsCode(%v, 400) = true, want false", codes)
+	}
+	if containsCode(nil, 200) {
+		t.Fatalf("containsCode(nil, 200) = true, want false")
+	}
+}
+
+func TestCloneRspAndCopyRsp(t *testing.T) {
+	type msg struct{ Value int }
+
+	original := &msg{Value: 1}
+	clone := cloneRsp(original).(*msg)
+	if clone == original {
+		t.Fatalf("cloneRsp returned the same pointer, want a distinct copy")
+	}
+	clone.Value = 42
+
+	if original.Value != 1 {
+		t.Fatalf("writing to the clone mutated the original: got %d, want 1", original.Value)
+	}
+
+	copyRsp(original, clone)
+	if original.Value != 42 {
+		t.Fatalf("copyRsp did not propagate the winning attempt: got %d, want 42", original.Value)
+	}
+}
+
+func TestCloneRspNonPointer(t *testing.T) {
+	// Non-pointer rsp values can't be usefully cloned; cloneRsp must hand the
+	// original back rather than panicking.
+	if got := cloneRsp(42); got != 42 {
+		t.Fatalf("cloneRsp(42) = %v, want 42", got)
+	}
+}
+
+func TestRetryAttempts(t *testing.T) {
+	ctx := contextWithRetryAttempts(context.Background())
+	if got := RetryAttempts(ctx); got != 0 {
+		t.Fatalf("RetryAttempts before any increment = %d, want 0", got)
+	}
+	incrRetryAttempts(ctx)
+	incrRetryAttempts(ctx)
+	if got := RetryAttempts(ctx); got != 2 {
+		t.Fatalf("RetryAttempts after two increments = %d, want 2", got)
+	}
+	if got := RetryAttempts(context.Background()); got != 0 {
+		t.Fatalf("RetryAttempts on an unrelated context = %d, want 0", got)
+	}
+}
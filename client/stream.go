@@ -0,0 +1,276 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"time"
+
+	"trpc.group/trpc-go/trpc-go/codec"
+	"trpc.group/trpc-go/trpc-go/errs"
+	"trpc.group/trpc-go/trpc-go/rpcz"
+	"trpc.group/trpc-go/trpc-go/transport"
+)
+
+// frameHeaderSize is the length, in bytes, of the big-endian uint32 message
+// length prefix written ahead of every SendMsg/read by every RecvMsg, so a
+// single logical stream can carry more than one delimited message without
+// RecvMsg blocking on io.ReadAll for data that will never arrive.
+const frameHeaderSize = 4
+
+// StreamDesc describes a client streaming RPC, mirroring whether the
+// caller-to-callee and callee-to-caller directions carry multiple messages.
+type StreamDesc struct {
+	// StreamName is the full name of the stream method, e.g. "/trpc.demo.Greeter/SayHelloStream".
+	StreamName string
+	// ClientStreams indicates the client sends more than one message.
+	ClientStreams bool
+	// ServerStreams indicates the server sends more than one message.
+	ServerStreams bool
+}
+
+// ClientStream is returned by Client.NewStream and used to send/receive
+// a sequence of messages to/from a backend service.
+type ClientStream interface {
+	// Context returns the context associated with the stream.
+	Context() context.Context
+	// SendMsg marshals, compresses and writes m onto the stream.
+	// On the client side of a unidirectional stream, SendMsg is called
+	// once followed by CloseSend. For bidi streams it may be called repeatedly.
+	SendMsg(m interface{}) error
+	// RecvMsg reads a message off the stream into m. It returns io.EOF
+	// when the stream has been closed normally by the server.
+	RecvMsg(m interface{}) error
+	// CloseSend signals that no more messages will be sent. It does not
+	// close the receiving side of the stream.
+	CloseSend() error
+}
+
+// StreamFilter intercepts the establishment of a client stream, analogous to
+// filter.ClientFilter for unary calls. Per-message interception happens inside
+// the returned ClientStream's SendMsg/RecvMsg.
+type StreamFilter func(ctx context.Context, desc *StreamDesc, streamer Streamer) (ClientStream, error)
+
+// Streamer creates a ClientStream after all StreamFilters in the chain have run.
+type Streamer func(ctx context.Context, desc *StreamDesc) (ClientStream, error)
+
+// StreamFilterChain is a chain of StreamFilter executed once, in order, at
+// stream establishment time.
+type StreamFilterChain []StreamFilter
+
+// Filter runs the chain, with streamer as the innermost call.
+func (c StreamFilterChain) Filter(ctx context.Context, desc *StreamDesc, streamer Streamer) (ClientStream, error) {
+	if len(c) == 0 {
+		return streamer(ctx, desc)
+	}
+	chain := streamer
+	for i := len(c) - 1; i >= 0; i-- {
+		chain = c.wrap(i, chain)
+	}
+	return chain(ctx, desc)
+}
+
+func (c StreamFilterChain) wrap(i int, next Streamer) Streamer {
+	return func(ctx context.Context, desc *StreamDesc) (ClientStream, error) {
+		return c[i](ctx, desc, next)
+	}
+}
+
+// NewStream begins a new server-streaming or bidi-streaming RPC. Unlike Invoke,
+// the selector and filter chain are only run once, at stream establishment;
+// per-message work (marshal/compress/encode) happens on every SendMsg/RecvMsg.
+func (c *client) NewStream(
+	ctx context.Context, desc *StreamDesc, method string, opt ...Option,
+) (ClientStream, error) {
+	ctx, msg := codec.EnsureMessage(ctx)
+	msg.WithClientRPCName(method)
+
+	span, end, ctx := rpcz.NewSpanContext(ctx, "client-stream")
+	defer end.End()
+
+	opts, err := c.getOptions(msg, opt...)
+	if err != nil {
+		return nil, err
+	}
+	c.updateMsg(msg, opts)
+
+	streamTransport, ok := opts.Transport.(transport.ClientStreamTransport)
+	if !ok {
+		return nil, errs.NewFrameError(errs.RetClientEncodeFail, "client: transport does not support streaming")
+	}
+
+	ctx = contextWithOptions(ctx, opts)
+	span.SetAttribute(rpcz.TRPCAttributeRPCName, msg.ClientRPCName())
+
+	return opts.StreamFilters.Filter(ctx, desc, func(ctx context.Context, desc *StreamDesc) (ClientStream, error) {
+		node, err := selectNode(ctx, msg, opts)
+		if err != nil {
+			return nil, err
+		}
+		ensureMsgRemoteAddr(msg, findFirstNonEmpty(node.Network, opts.Network), node.Address)
+
+		callOpts := opts.CallOptions
+		if opts.InitialWindowSize > 0 {
+			callOpts = append(callOpts, transport.WithInitialWindowSize(opts.InitialWindowSize))
+		}
+		if addr := msg.RemoteAddr(); addr != nil {
+			callOpts = append(callOpts, transport.WithTarget(addr.Network(), addr.String()))
+		}
+
+		// ctx is cancelled once the stream is done with (CloseSend plus a
+		// terminal RecvMsg), so a Pool-backed transport's stream-tracking
+		// goroutine (see pooledConn.NewStream) can clean up without the caller
+		// having to cancel its own, possibly long-lived, context.
+		streamCtx, cancel := context.WithCancel(ctx)
+		begin := time.Now()
+		frame, err := streamTransport.NewStream(streamCtx, callOpts...)
+		if err != nil {
+			cancel()
+			opts.Selector.Report(node, time.Since(begin), err)
+			return nil, err
+		}
+		opts.Selector.Report(node, time.Since(begin), nil)
+		if addr := msg.RemoteAddr(); addr != nil {
+			opts.Node.set(node, addr.String(), time.Since(begin))
+		} else {
+			opts.Node.set(node, node.Address, time.Since(begin))
+		}
+		return newClientStream(streamCtx, msg, opts, frame, desc, cancel), nil
+	})
+}
+
+// clientStream is the default ClientStream implementation, framing each
+// message over the long-lived io.ReadWriter handed back by the transport.
+type clientStream struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	msg    codec.Msg
+	opts   *Options
+	frame  io.ReadWriter
+	desc   *StreamDesc
+	closed bool
+}
+
+func newClientStream(
+	ctx context.Context, msg codec.Msg, opts *Options, frame io.ReadWriter, desc *StreamDesc, cancel context.CancelFunc,
+) *clientStream {
+	return &clientStream{ctx: ctx, cancel: cancel, msg: msg, opts: opts, frame: frame, desc: desc}
+}
+
+func (s *clientStream) Context() context.Context { return s.ctx }
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	buf, err := serializeAndCompress(s.ctx, s.msg, m, s.opts)
+	if err != nil {
+		return err
+	}
+	if max := s.opts.MaxSendMsgSize; max > 0 && len(buf) > max {
+		return errs.NewFrameError(errs.RetClientEncodeFail, "client: send message larger than MaxSendMsgSize")
+	}
+	reqBuf, err := s.opts.Codec.Encode(s.msg, buf)
+	if err != nil {
+		return errs.NewFrameError(errs.RetClientEncodeFail, "client stream codec Encode: "+err.Error())
+	}
+
+	var header [frameHeaderSize]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(reqBuf)))
+	if _, err := s.frame.Write(header[:]); err != nil {
+		return err
+	}
+	_, err = s.frame.Write(reqBuf)
+	return err
+}
+
+// RecvMsg cancels s.ctx on any terminal outcome — including the normal
+// io.EOF completion, not just an explicit error — so the stream-tracking
+// goroutine a Pool-backed transport starts for this stream (see
+// pooledConn.NewStream) is released as soon as this stream is actually done,
+// instead of only when the caller's own, possibly long-lived, context ends.
+func (s *clientStream) RecvMsg(m interface{}) (err error) {
+	defer func() {
+		if err != nil {
+			s.cancel()
+		}
+	}()
+
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(s.frame, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return err
+	}
+	length := binary.BigEndian.Uint32(header[:])
+	if max := s.recvLimit(); max > 0 && length > uint32(max) {
+		return errs.NewFrameError(errs.RetClientDecodeFail, "client stream: message larger than MaxRecvMsgSize")
+	}
+
+	rspBuf := make([]byte, length)
+	if _, err := io.ReadFull(s.frame, rspBuf); err != nil {
+		return err
+	}
+
+	rspBodyBuf, err := s.opts.Codec.Decode(s.msg, rspBuf)
+	if err != nil {
+		return errs.NewFrameError(errs.RetClientDecodeFail, "client stream codec Decode: "+err.Error())
+	}
+	return processResponseBuf(s.ctx, s.msg, m, rspBodyBuf, s.opts)
+}
+
+func (s *clientStream) recvLimit() int {
+	if s.opts.MaxRecvMsgSize > 0 {
+		return s.opts.MaxRecvMsgSize
+	}
+	return defaultMaxRecvMsgSize
+}
+
+func (s *clientStream) CloseSend() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if closer, ok := s.frame.(interface{ CloseWrite() error }); ok {
+		return closer.CloseWrite()
+	}
+	return nil
+}
+
+// defaultMaxRecvMsgSize is used when Options.MaxRecvMsgSize is unset (<= 0).
+const defaultMaxRecvMsgSize = 4 << 20
+
+// WithMaxSendMsgSize sets the maximum size in bytes of a single message
+// SendMsg is allowed to write on a stream. <= 0 means unlimited.
+func WithMaxSendMsgSize(size int) Option {
+	return func(o *Options) { o.MaxSendMsgSize = size }
+}
+
+// WithMaxRecvMsgSize sets the maximum size in bytes of a single message
+// RecvMsg is allowed to read off a stream. <= 0 falls back to defaultMaxRecvMsgSize.
+func WithMaxRecvMsgSize(size int) Option {
+	return func(o *Options) { o.MaxRecvMsgSize = size }
+}
+
+// WithInitialWindowSize sets the initial flow-control window, in bytes,
+// handed to the stream transport via transport.WithInitialWindowSize when a
+// stream is established. transport.Pool does not read it.
+func WithInitialWindowSize(size int) Option {
+	return func(o *Options) { o.InitialWindowSize = size }
+}
+
+// WithStreamFilter appends f to the chain run once at stream establishment.
+func WithStreamFilter(f StreamFilter) Option {
+	return func(o *Options) { o.StreamFilters = append(o.StreamFilters, f) }
+}
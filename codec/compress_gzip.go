@@ -0,0 +1,39 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package codec
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+func init() {
+	RegisterCompressor(&gzipCompressor{})
+}
+
+// gzipCompressor is the streaming Compressor backed by compress/gzip.
+type gzipCompressor struct{}
+
+// Name implements Compressor.
+func (*gzipCompressor) Name() string { return "gzip" }
+
+// NewWriter implements Compressor.
+func (*gzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+// NewReader implements Compressor.
+func (*gzipCompressor) NewReader(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
@@ -0,0 +1,41 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package codec
+
+import "io"
+
+func init() {
+	RegisterCompressor(&identityCompressor{})
+}
+
+// identityCompressor is the streaming Compressor counterpart of
+// CompressTypeNoop: it passes bytes through unchanged.
+type identityCompressor struct{}
+
+// Name implements Compressor.
+func (*identityCompressor) Name() string { return "identity" }
+
+// NewWriter implements Compressor.
+func (*identityCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+// NewReader implements Compressor.
+func (*identityCompressor) NewReader(r io.Reader) (io.Reader, error) {
+	return r, nil
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,56 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package codec
+
+import "io"
+
+// Compressor streams payloads through a named compression algorithm. Unlike
+// the legacy int CompressType enum consumed by Compress/Decompress, a
+// Compressor wraps an io.Reader/io.Writer so large payloads don't have to be
+// fully buffered before compressing or decompressing.
+type Compressor interface {
+	// Name identifies the algorithm on the wire, e.g. in the
+	// trpc-accept-encoding metadata header. Must match the name passed to
+	// RegisterCompressor.
+	Name() string
+	// NewWriter wraps w so bytes written through it are compressed before
+	// reaching w. Callers must Close the returned writer to flush trailing data.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	// NewReader wraps r so bytes read through it are the decompressed form of r.
+	NewReader(r io.Reader) (io.Reader, error)
+}
+
+var compressorRegistry = map[string]Compressor{}
+
+// RegisterCompressor registers c under c.Name(), overwriting any previous
+// registration of the same name. Typically called from an init() function.
+func RegisterCompressor(c Compressor) {
+	compressorRegistry[c.Name()] = c
+}
+
+// GetCompressor looks up a Compressor previously passed to RegisterCompressor.
+func GetCompressor(name string) (Compressor, bool) {
+	c, ok := compressorRegistry[name]
+	return c, ok
+}
+
+// RegisteredCompressorNames lists every registered Compressor name, used to
+// populate the accept-encoding-style metadata header advertised by clients.
+func RegisteredCompressorNames() []string {
+	names := make([]string, 0, len(compressorRegistry))
+	for name := range compressorRegistry {
+		names = append(names, name)
+	}
+	return names
+}
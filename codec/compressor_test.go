@@ -0,0 +1,81 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package codec
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestGzipCompressorRoundTrip(t *testing.T) {
+	c, ok := GetCompressor("gzip")
+	if !ok {
+		t.Fatalf(`GetCompressor("gzip") not found, want registered by compress_gzip.go's init`)
+	}
+
+	const want = "hello trpc-go compressor registry"
+	var compressed bytes.Buffer
+	w, err := c.NewWriter(&compressed)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	if _, err := w.Write([]byte(want)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	r, err := c.NewReader(&compressed)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != want {
+		t.Fatalf("round trip = %q, want %q", got, want)
+	}
+}
+
+func TestIdentityCompressorPassesThrough(t *testing.T) {
+	c, ok := GetCompressor("identity")
+	if !ok {
+		t.Fatalf(`GetCompressor("identity") not found, want registered by compress_identity.go's init`)
+	}
+
+	const want = "unchanged"
+	var buf bytes.Buffer
+	w, _ := c.NewWriter(&buf)
+	_, _ = w.Write([]byte(want))
+	_ = w.Close()
+	if buf.String() != want {
+		t.Fatalf("identity writer transformed input: got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRegisteredCompressorNamesIncludesGzipAndIdentity(t *testing.T) {
+	names := RegisteredCompressorNames()
+	seen := make(map[string]bool, len(names))
+	for _, n := range names {
+		seen[n] = true
+	}
+	for _, want := range []string{"gzip", "identity"} {
+		if !seen[want] {
+			t.Fatalf("RegisteredCompressorNames() = %v, want it to include %q", names, want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package codec
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// TimeoutMetaKey is the reserved metadata key carrying the caller's remaining
+// full-link deadline on the wire, gRPC's "grpc-timeout" header in trpc form.
+const TimeoutMetaKey = "trpc-timeout"
+
+// timeoutUnit is, in ascending order of granularity, the same unit set
+// grpc-go's timeoutEncode uses: H(our) M(inute) S(econd) m(illi) u(micro) n(ano).
+type timeoutUnit byte
+
+const (
+	unitHour        timeoutUnit = 'H'
+	unitMinute      timeoutUnit = 'M'
+	unitSecond      timeoutUnit = 'S'
+	unitMillisecond timeoutUnit = 'm'
+	unitMicrosecond timeoutUnit = 'u'
+	unitNanosecond  timeoutUnit = 'n'
+)
+
+func (u timeoutUnit) duration() time.Duration {
+	switch u {
+	case unitHour:
+		return time.Hour
+	case unitMinute:
+		return time.Minute
+	case unitSecond:
+		return time.Second
+	case unitMillisecond:
+		return time.Millisecond
+	case unitMicrosecond:
+		return time.Microsecond
+	default:
+		return time.Nanosecond
+	}
+}
+
+// maxTimeoutValue is the exclusive upper bound on the value EncodeTimeout
+// emits before the unit, so the value never exceeds 8 digits; a longer wire
+// token would overflow gRPC-style timeout parsers that cap it at 8 digits.
+const maxTimeoutValue = 100000000
+
+// EncodeTimeout formats d as a "<value><unit>" string whose value has at
+// most 8 digits (mirroring gRPC's wire timeout
+// encoding), so a deadline always survives the hop as a small, readable token.
+// A duration that divides evenly into one of the coarser units (e.g. 5s, or
+// 2*time.Minute) is always encoded in that unit; only durations with no exact
+// coarse representation fall back to the finest unit that still fits.
+func EncodeTimeout(d time.Duration) string {
+	if d <= 0 {
+		return "0n"
+	}
+	for _, u := range []timeoutUnit{unitHour, unitMinute, unitSecond, unitMillisecond, unitMicrosecond, unitNanosecond} {
+		unitDur := u.duration()
+		if d%unitDur == 0 {
+			if v := d / unitDur; v < maxTimeoutValue {
+				return strconv.FormatInt(int64(v), 10) + string(u)
+			}
+		}
+	}
+	for _, u := range []timeoutUnit{unitNanosecond, unitMicrosecond, unitMillisecond, unitSecond, unitMinute, unitHour} {
+		if v := d / u.duration(); v < maxTimeoutValue {
+			return strconv.FormatInt(int64(v), 10) + string(u)
+		}
+	}
+	return strconv.FormatInt(int64(d/time.Hour), 10) + string(unitHour)
+}
+
+// DecodeTimeout parses a string previously produced by EncodeTimeout back
+// into a time.Duration.
+func DecodeTimeout(s string) (time.Duration, error) {
+	if len(s) < 2 {
+		return 0, fmt.Errorf("codec: invalid trpc-timeout value %q", s)
+	}
+	unit := timeoutUnit(s[len(s)-1])
+	switch unit {
+	case unitHour, unitMinute, unitSecond, unitMillisecond, unitMicrosecond, unitNanosecond:
+	default:
+		return 0, fmt.Errorf("codec: invalid trpc-timeout unit in %q", s)
+	}
+	v, err := strconv.ParseInt(s[:len(s)-1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("codec: invalid trpc-timeout value %q: %w", s, err)
+	}
+	return time.Duration(v) * unit.duration(), nil
+}
+
+// WithIncomingDeadline is the server-side counterpart of the client writing
+// TimeoutMetaKey: it reads the caller's remaining budget out of md and, if
+// present, installs it as ctx's own deadline before dispatch so handlers see
+// the caller's timeout rather than their own unbounded context. A zero or
+// negative budget yields a ctx whose deadline has already passed.
+func WithIncomingDeadline(ctx context.Context, md MetaData) (context.Context, context.CancelFunc, error) {
+	raw, ok := md[TimeoutMetaKey]
+	if !ok {
+		return ctx, func() {}, nil
+	}
+	remaining, err := DecodeTimeout(string(raw))
+	if err != nil {
+		return ctx, func() {}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, remaining)
+	return ctx, cancel, nil
+}
@@ -0,0 +1,102 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package codec
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEncodeTimeoutPicksCoarsestExactUnit(t *testing.T) {
+	cases := []struct {
+		d    time.Duration
+		want string
+	}{
+		{5 * time.Second, "5S"},
+		{2 * time.Minute, "2M"},
+		{3 * time.Hour, "3H"},
+		{1500 * time.Millisecond, "1500m"},
+		{1234567 * time.Nanosecond, "1234567n"},
+		{0, "0n"},
+		{-time.Second, "0n"},
+	}
+	for _, c := range cases {
+		if got := EncodeTimeout(c.d); got != c.want {
+			t.Errorf("EncodeTimeout(%v) = %q, want %q", c.d, got, c.want)
+		}
+	}
+}
+
+func TestEncodeDecodeTimeoutRoundTrip(t *testing.T) {
+	durations := []time.Duration{
+		time.Nanosecond, time.Microsecond, time.Millisecond,
+		time.Second, time.Minute, time.Hour,
+		5 * time.Second, 90 * time.Minute, 1234567 * time.Nanosecond,
+	}
+	for _, d := range durations {
+		encoded := EncodeTimeout(d)
+		decoded, err := DecodeTimeout(encoded)
+		if err != nil {
+			t.Fatalf("DecodeTimeout(%q) (encoding %v) failed: %v", encoded, d, err)
+		}
+		if decoded != d {
+			t.Fatalf("round trip of %v via %q = %v, want %v", d, encoded, decoded, d)
+		}
+	}
+}
+
+func TestDecodeTimeoutInvalid(t *testing.T) {
+	for _, s := range []string{"", "5", "5X", "abcS"} {
+		if _, err := DecodeTimeout(s); err == nil {
+			t.Errorf("DecodeTimeout(%q) = nil error, want error", s)
+		}
+	}
+}
+
+func TestWithIncomingDeadlineInstallsRemainingBudget(t *testing.T) {
+	md := MetaData{TimeoutMetaKey: []byte(EncodeTimeout(5 * time.Second))}
+	ctx, cancel, err := WithIncomingDeadline(context.Background(), md)
+	defer cancel()
+	if err != nil {
+		t.Fatalf("WithIncomingDeadline: %v", err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatalf("ctx has no deadline, want one installed from %q", md[TimeoutMetaKey])
+	}
+	if remaining := time.Until(deadline); remaining <= 0 || remaining > 5*time.Second {
+		t.Fatalf("remaining budget = %v, want in (0, 5s]", remaining)
+	}
+}
+
+func TestWithIncomingDeadlineNoMetadataIsNoop(t *testing.T) {
+	ctx, cancel, err := WithIncomingDeadline(context.Background(), MetaData{})
+	defer cancel()
+	if err != nil {
+		t.Fatalf("WithIncomingDeadline: %v", err)
+	}
+	if _, ok := ctx.Deadline(); ok {
+		t.Fatalf("ctx has a deadline, want none since md carried no trpc-timeout")
+	}
+}
+
+func TestWithIncomingDeadlineInvalidMetadata(t *testing.T) {
+	md := MetaData{TimeoutMetaKey: []byte("garbage")}
+	_, cancel, err := WithIncomingDeadline(context.Background(), md)
+	defer cancel()
+	if err == nil {
+		t.Fatalf("WithIncomingDeadline with invalid %q = nil error, want error", md[TimeoutMetaKey])
+	}
+}
@@ -0,0 +1,28 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package codec
+
+import "trpc.group/trpc-go/trpc-go/codec"
+
+// IsValidCompressorName extends IsValidCompressType to the name-based
+// streaming compressor registry (codec.RegisterCompressor/GetCompressor):
+// it reports whether name refers to a Compressor that was actually registered,
+// so callers don't advertise/select a compressor that doesn't exist.
+func IsValidCompressorName(name string) bool {
+	if name == "" {
+		return false
+	}
+	_, ok := codec.GetCompressor(name)
+	return ok
+}
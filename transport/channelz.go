@@ -0,0 +1,131 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package transport
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Socket is a channelz-style snapshot of one pooled connection, mirroring
+// gRPC's channelz socket view: sockets, not channels or subchannels, since
+// Pool only multiplexes over already-resolved (network, address) endpoints.
+type Socket struct {
+	ID         int64
+	LocalAddr  string
+	RemoteAddr string
+	Created    time.Time
+
+	RPCsStarted   atomic.Int64
+	RPCsSucceeded atomic.Int64
+	RPCsFailed    atomic.Int64
+	BytesSent     atomic.Uint64
+	BytesReceived atomic.Uint64
+
+	mu        sync.Mutex
+	lastError error
+}
+
+func (s *Socket) setLastError(err error) {
+	s.mu.Lock()
+	s.lastError = err
+	s.mu.Unlock()
+}
+
+func (s *Socket) lastErrorString() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastError == nil {
+		return ""
+	}
+	return s.lastError.Error()
+}
+
+var (
+	socketIDs     atomic.Int64
+	socketsMu     sync.Mutex
+	liveSockets   = map[int64]*Socket{}
+)
+
+func newSocket(key PoolKey, conn net.Conn) *Socket {
+	s := &Socket{
+		ID:         socketIDs.Add(1),
+		LocalAddr:  conn.LocalAddr().String(),
+		RemoteAddr: conn.RemoteAddr().String(),
+		Created:    time.Now(),
+	}
+	socketsMu.Lock()
+	liveSockets[s.ID] = s
+	socketsMu.Unlock()
+	return s
+}
+
+// unregisterSocket retires id from liveSockets once its pooledConn is
+// closed, so ChannelzHandler stops reporting a dead connection as live.
+func unregisterSocket(id int64) {
+	socketsMu.Lock()
+	delete(liveSockets, id)
+	socketsMu.Unlock()
+}
+
+// socketView is the JSON shape served by ChannelzHandler, one entry per live
+// pooled connection.
+type socketView struct {
+	ID            int64  `json:"id"`
+	LocalAddr     string `json:"local_addr"`
+	RemoteAddr    string `json:"remote_addr"`
+	CreatedAt     string `json:"created_at"`
+	RPCsStarted   int64  `json:"rpcs_started"`
+	RPCsSucceeded int64  `json:"rpcs_succeeded"`
+	RPCsFailed    int64  `json:"rpcs_failed"`
+	BytesSent     uint64 `json:"bytes_sent"`
+	BytesReceived uint64 `json:"bytes_received"`
+	LastError     string `json:"last_error,omitempty"`
+}
+
+// ChannelzHandler serves a JSON snapshot of every pooled connection's
+// runtime state at /debug/trpc/channelz, analogous to gRPC channelz.
+func ChannelzHandler(w http.ResponseWriter, r *http.Request) {
+	socketsMu.Lock()
+	views := make([]socketView, 0, len(liveSockets))
+	for _, s := range liveSockets {
+		views = append(views, socketView{
+			ID:            s.ID,
+			LocalAddr:     s.LocalAddr,
+			RemoteAddr:    s.RemoteAddr,
+			CreatedAt:     s.Created.Format(time.RFC3339),
+			RPCsStarted:   s.RPCsStarted.Load(),
+			RPCsSucceeded: s.RPCsSucceeded.Load(),
+			RPCsFailed:    s.RPCsFailed.Load(),
+			BytesSent:     s.BytesSent.Load(),
+			BytesReceived: s.BytesReceived.Load(),
+			LastError:     s.lastErrorString(),
+		})
+	}
+	socketsMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(views)
+}
+
+// RegisterChannelzHandler mounts ChannelzHandler on mux at the conventional
+// /debug/trpc/channelz path. Callers own mux (typically the admin service's),
+// so plugging this in never implicitly touches http.DefaultServeMux.
+func RegisterChannelzHandler(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/trpc/channelz", ChannelzHandler)
+}
@@ -0,0 +1,458 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Picker chooses one of conns for the next stream, cooperating with the
+// client-side selector: the selector already picked a node (network+address),
+// Picker only decides which pooled connection to that node to multiplex onto.
+type Picker func(conns []*pooledConn) *pooledConn
+
+// PickFirst always returns the first usable connection, opening a new one
+// only when none exists yet.
+func PickFirst(conns []*pooledConn) *pooledConn {
+	if len(conns) == 0 {
+		return nil
+	}
+	return conns[0]
+}
+
+// RoundRobin cycles through conns, spreading streams evenly across them.
+func RoundRobin(conns []*pooledConn) *pooledConn {
+	if len(conns) == 0 {
+		return nil
+	}
+	n := atomic.AddUint64(&roundRobinCursor, 1)
+	return conns[int(n%uint64(len(conns)))]
+}
+
+var roundRobinCursor uint64
+
+// PoolConfig configures a Pool.
+type PoolConfig struct {
+	// MaxConcurrentStreamsPerConn caps in-flight multiplexed streams per conn
+	// before the pool opens another connection to the same key.
+	MaxConcurrentStreamsPerConn int
+	// MaxConnsPerHost caps the number of pooled connections per PoolKey.
+	MaxConnsPerHost int
+	// IdleTimeout evicts a connection that has carried no stream for this long.
+	IdleTimeout time.Duration
+	// Picker selects a connection among the ones already open for a key.
+	// Defaults to PickFirst.
+	Picker Picker
+	// Dial opens a new connection for key. Defaults to net.Dial/tls.Dial
+	// depending on whether key.TLSConfig is set.
+	Dial func(ctx context.Context, key PoolKey) (net.Conn, error)
+}
+
+// PoolKey identifies a poolable destination: same network/address/TLS config
+// connections are eligible to be multiplexed together.
+type PoolKey struct {
+	Network   string
+	Address   string
+	TLSConfig *tls.Config
+}
+
+// Pool maintains a bounded set of long-lived, HTTP/2-style multiplexed
+// connections per PoolKey, handed out through opts.Transport.RoundTrip
+// without changing the existing one-shot transport's public behaviour.
+type Pool struct {
+	config PoolConfig
+
+	mu    sync.Mutex
+	conns map[PoolKey][]*pooledConn
+}
+
+// NewPool creates a Pool. Zero-valued fields of config fall back to sane
+// defaults (PickFirst, unbounded MaxConnsPerHost, no idle eviction).
+func NewPool(config PoolConfig) *Pool {
+	if config.Picker == nil {
+		config.Picker = PickFirst
+	}
+	return &Pool{config: config, conns: make(map[PoolKey][]*pooledConn)}
+}
+
+// Get returns a pooled connection for key, dialing a new one if every
+// existing connection is at MaxConcurrentStreamsPerConn or none exist yet.
+func (p *Pool) Get(ctx context.Context, key PoolKey) (*pooledConn, error) {
+	p.mu.Lock()
+	conns := p.conns[key]
+	if c := p.pickUsable(conns); c != nil {
+		p.mu.Unlock()
+		return c, nil
+	}
+	if p.config.MaxConnsPerHost > 0 && len(conns) >= p.config.MaxConnsPerHost {
+		// At capacity: fall back to whatever the picker returns, even if busy.
+		c := p.config.Picker(conns)
+		p.mu.Unlock()
+		if c == nil {
+			return nil, fmt.Errorf("transport: pool at capacity for %s %s", key.Network, key.Address)
+		}
+		return c, nil
+	}
+	p.mu.Unlock()
+
+	conn, err := p.dial(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	pc := newPooledConn(conn, key, p.config.MaxConcurrentStreamsPerConn)
+
+	p.mu.Lock()
+	p.conns[key] = append(p.conns[key], pc)
+	p.mu.Unlock()
+
+	go p.evictWhenIdle(key, pc)
+	return pc, nil
+}
+
+func (p *Pool) pickUsable(conns []*pooledConn) *pooledConn {
+	usable := conns[:0:0]
+	for _, c := range conns {
+		if !c.closed.Load() && (p.config.MaxConcurrentStreamsPerConn <= 0 ||
+			c.streamCount() < p.config.MaxConcurrentStreamsPerConn) {
+			usable = append(usable, c)
+		}
+	}
+	return p.config.Picker(usable)
+}
+
+func (p *Pool) dial(ctx context.Context, key PoolKey) (net.Conn, error) {
+	if p.config.Dial != nil {
+		return p.config.Dial(ctx, key)
+	}
+	d := &net.Dialer{}
+	if key.TLSConfig != nil {
+		return tls.DialWithDialer(d, key.Network, key.Address, key.TLSConfig)
+	}
+	return d.DialContext(ctx, key.Network, key.Address)
+}
+
+// dialStream resolves opt's target into a PoolKey, gets or opens a
+// pooledConn for it, and opens a fresh multiplexed logical stream on it.
+func (p *Pool) dialStream(ctx context.Context, opt ...RoundTripOption) (*muxStream, error) {
+	ro := resolveRoundTripOptions(opt...)
+	pc, err := p.Get(ctx, PoolKey{Network: ro.Network, Address: ro.Address})
+	if err != nil {
+		return nil, err
+	}
+	return pc.NewStream(ctx)
+}
+
+// NewStream implements transport.ClientStreamTransport on top of Pool, so a
+// client.Client configured with client.WithConnPool can actually establish a
+// streaming RPC instead of opts.Transport failing the ClientStreamTransport
+// type assertion. opt must include WithTarget so dialStream knows which
+// PoolKey to get or dial.
+func (p *Pool) NewStream(ctx context.Context, opt ...RoundTripOption) (io.ReadWriter, error) {
+	return p.dialStream(ctx, opt...)
+}
+
+// RoundTrip implements the package's one-shot ClientTransport contract on
+// top of Pool: it opens a multiplexed logical stream to opt's target,
+// writes req as the single request frame, and returns the single response
+// frame. This is the integration point client.WithConnPool needs — without
+// it, Pool.Get/pooledConn are only reachable from this package's own tests,
+// and real unary traffic never touches the pool at all.
+func (p *Pool) RoundTrip(ctx context.Context, req []byte, opt ...RoundTripOption) ([]byte, error) {
+	// Cancelling on return retires the logical stream from its pooledConn
+	// (see pooledConn.NewStream) once this unary call is done with it.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s, err := p.dialStream(ctx, opt...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.Write(req); err != nil {
+		return nil, err
+	}
+	return s.ReadFrame()
+}
+
+func (p *Pool) evictWhenIdle(key PoolKey, pc *pooledConn) {
+	if p.config.IdleTimeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(p.config.IdleTimeout / 2)
+	defer ticker.Stop()
+	for range ticker.C {
+		if pc.closed.Load() {
+			return
+		}
+		if pc.streamCount() == 0 && time.Since(pc.lastActive()) > p.config.IdleTimeout {
+			pc.Close()
+			p.mu.Lock()
+			p.conns[key] = removeConn(p.conns[key], pc)
+			p.mu.Unlock()
+			return
+		}
+	}
+}
+
+func removeConn(conns []*pooledConn, target *pooledConn) []*pooledConn {
+	out := conns[:0]
+	for _, c := range conns {
+		if c != target {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// pooledConn multiplexes many logical streams over a single net.Conn using a
+// minimal HTTP/2-like frame: a 4-byte stream ID followed by a 4-byte length
+// and the payload, so concurrent RPCs share one TCP connection instead of
+// each opening its own (the classic EnableMultiplexed path bypasses this).
+type pooledConn struct {
+	conn net.Conn
+	key  PoolKey
+
+	maxStreams int
+	socket     *Socket
+
+	// writeMu serializes writes onto conn: write() writes a frame header and
+	// its payload as two separate conn.Write calls, and without a lock
+	// spanning both, concurrent muxStreams could interleave their frames.
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	nextID   uint32
+	streams  map[uint32]*muxStream
+	lastUsed time.Time
+	closed   atomic.Bool
+}
+
+func newPooledConn(conn net.Conn, key PoolKey, maxStreams int) *pooledConn {
+	pc := &pooledConn{
+		conn:       conn,
+		key:        key,
+		maxStreams: maxStreams,
+		streams:    make(map[uint32]*muxStream),
+		lastUsed:   time.Now(),
+		socket:     newSocket(key, conn),
+	}
+	go pc.readLoop()
+	return pc
+}
+
+func (pc *pooledConn) streamCount() int {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return len(pc.streams)
+}
+
+func (pc *pooledConn) lastActive() time.Time {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.lastUsed
+}
+
+// NewStream opens a new multiplexed logical stream, implementing the same
+// io.ReadWriter shape transport.ClientStreamTransport.NewStream returns for
+// the non-pooled path, so client.ClientStream works unmodified over it. The
+// stream's entry in pc.streams is removed once ctx is done, so an abandoned
+// stream doesn't leak forever.
+func (pc *pooledConn) NewStream(ctx context.Context) (*muxStream, error) {
+	s := newMuxStream(pc)
+
+	pc.mu.Lock()
+	id := pc.nextID
+	pc.nextID++
+	pc.lastUsed = time.Now()
+	s.id = id
+	pc.streams[id] = s
+	pc.mu.Unlock()
+
+	pc.socket.RPCsStarted.Add(1)
+	go func() {
+		<-ctx.Done()
+		pc.removeStream(id)
+		s.closeQueue()
+	}()
+	return s, nil
+}
+
+// removeStream deletes id from pc.streams so readLoop stops delivering to it
+// and its *muxStream becomes eligible for GC.
+func (pc *pooledConn) removeStream(id uint32) {
+	pc.mu.Lock()
+	delete(pc.streams, id)
+	pc.mu.Unlock()
+}
+
+func (pc *pooledConn) write(id uint32, payload []byte) error {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[0:4], id)
+	binary.BigEndian.PutUint32(header[4:8], uint32(len(payload)))
+
+	pc.mu.Lock()
+	pc.lastUsed = time.Now()
+	pc.mu.Unlock()
+
+	pc.writeMu.Lock()
+	defer pc.writeMu.Unlock()
+	if _, err := pc.conn.Write(header[:]); err != nil {
+		pc.socket.setLastError(err)
+		return err
+	}
+	if _, err := pc.conn.Write(payload); err != nil {
+		pc.socket.setLastError(err)
+		return err
+	}
+	pc.socket.BytesSent.Add(uint64(len(payload)))
+	return nil
+}
+
+func (pc *pooledConn) readLoop() {
+	var header [8]byte
+	for {
+		if _, err := io.ReadFull(pc.conn, header[:]); err != nil {
+			pc.socket.setLastError(err)
+			pc.Close()
+			return
+		}
+		id := binary.BigEndian.Uint32(header[0:4])
+		length := binary.BigEndian.Uint32(header[4:8])
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(pc.conn, payload); err != nil {
+			pc.socket.setLastError(err)
+			pc.Close()
+			return
+		}
+		pc.socket.BytesReceived.Add(uint64(len(payload)))
+
+		pc.mu.Lock()
+		s := pc.streams[id]
+		pc.mu.Unlock()
+		if s != nil {
+			s.push(payload)
+		}
+	}
+}
+
+// Close closes the underlying connection and every stream still multiplexed
+// on it, and retires its Socket from the channelz live-socket set.
+func (pc *pooledConn) Close() error {
+	if !pc.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	pc.mu.Lock()
+	for _, s := range pc.streams {
+		s.closeQueue()
+	}
+	pc.mu.Unlock()
+	unregisterSocket(pc.socket.ID)
+	return pc.conn.Close()
+}
+
+// muxStream is a single logical stream multiplexed on a pooledConn. Frames
+// delivered by readLoop are appended to queue rather than sent on a channel:
+// readLoop is the single goroutine feeding every stream on this conn, so a
+// stream whose consumer reads slowly (or not at all) must never block that
+// append, or it would stall delivery to every other multiplexed stream too
+// (head-of-line blocking).
+type muxStream struct {
+	id   uint32
+	conn *pooledConn
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  [][]byte
+	closed bool
+	buf    []byte
+}
+
+func newMuxStream(conn *pooledConn) *muxStream {
+	s := &muxStream{conn: conn}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// push appends frame for Read to consume. It never blocks on the consumer.
+func (s *muxStream) push(frame []byte) {
+	s.mu.Lock()
+	s.queue = append(s.queue, frame)
+	s.mu.Unlock()
+	s.cond.Signal()
+}
+
+// closeQueue marks the stream done, waking any Read blocked on more data.
+func (s *muxStream) closeQueue() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+// Write implements io.Writer.
+func (s *muxStream) Write(p []byte) (int, error) {
+	if err := s.conn.write(s.id, p); err != nil {
+		s.conn.socket.RPCsFailed.Add(1)
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Read implements io.Reader.
+func (s *muxStream) Read(p []byte) (int, error) {
+	for len(s.buf) == 0 {
+		s.mu.Lock()
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			s.conn.socket.RPCsSucceeded.Add(1)
+			return 0, io.EOF
+		}
+		frame := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+		s.buf = frame
+	}
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	return n, nil
+}
+
+// ReadFrame blocks for the next frame readLoop delivers and returns it
+// whole, unlike Read, which copies into a caller buffer and may split one
+// frame across several calls. Pool.RoundTrip uses this to read exactly the
+// one response frame a unary call's logical stream ever carries.
+func (s *muxStream) ReadFrame() ([]byte, error) {
+	s.mu.Lock()
+	for len(s.queue) == 0 && !s.closed {
+		s.cond.Wait()
+	}
+	if len(s.queue) == 0 {
+		s.mu.Unlock()
+		s.conn.socket.RPCsSucceeded.Add(1)
+		return nil, io.EOF
+	}
+	frame := s.queue[0]
+	s.queue = s.queue[1:]
+	s.mu.Unlock()
+	return frame, nil
+}
@@ -0,0 +1,110 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingConn is a net.Conn whose Write calls are recorded verbatim, so
+// tests can tell whether two concurrent writers interleaved their frames.
+type recordingConn struct {
+	net.Conn
+	mu     sync.Mutex
+	writes [][]byte
+}
+
+func (c *recordingConn) Write(p []byte) (int, error) {
+	cp := append([]byte(nil), p...)
+	c.mu.Lock()
+	c.writes = append(c.writes, cp)
+	c.mu.Unlock()
+	return len(p), nil
+}
+
+func (c *recordingConn) Read(p []byte) (int, error) {
+	select {}
+}
+
+func (c *recordingConn) Close() error { return nil }
+
+func (c *recordingConn) LocalAddr() net.Addr { return &net.TCPAddr{} }
+
+func (c *recordingConn) RemoteAddr() net.Addr { return &net.TCPAddr{} }
+
+func TestPooledConnWriteDoesNotInterleaveFrames(t *testing.T) {
+	conn := &recordingConn{}
+	pc := newPooledConn(conn, PoolKey{Network: "tcp", Address: "test"}, 0)
+
+	const n = 20
+	payload := make([]byte, 100)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(id uint32) {
+			defer wg.Done()
+			_ = pc.write(id, payload)
+		}(uint32(i))
+	}
+	wg.Wait()
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if len(conn.writes) != 2*n {
+		t.Fatalf("got %d conn.Write calls, want %d (one header + one payload per write)", len(conn.writes), 2*n)
+	}
+	// Every header write must be followed immediately by the payload of the
+	// same length it announces; an interleaved header/payload pair from two
+	// different writers would break this invariant.
+	for i := 0; i < len(conn.writes); i += 2 {
+		header := conn.writes[i]
+		body := conn.writes[i+1]
+		if len(header) != 8 {
+			t.Fatalf("writes[%d] length = %d, want 8-byte header", i, len(header))
+		}
+		length := binary.BigEndian.Uint32(header[4:8])
+		if int(length) != len(body) {
+			t.Fatalf("header at writes[%d] announces length %d, but next write has length %d", i, length, len(body))
+		}
+	}
+}
+
+func TestPooledConnNewStreamRemovedOnContextDone(t *testing.T) {
+	conn := &recordingConn{}
+	pc := newPooledConn(conn, PoolKey{Network: "tcp", Address: "test"}, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s, err := pc.NewStream(ctx)
+	if err != nil {
+		t.Fatalf("NewStream: %v", err)
+	}
+	if pc.streamCount() != 1 {
+		t.Fatalf("streamCount() = %d, want 1", pc.streamCount())
+	}
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for pc.streamCount() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("streamCount() still %d after context cancellation, want 0 (stream leaked)", pc.streamCount())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	_ = s
+}
@@ -0,0 +1,68 @@
+//
+//
+// Tencent is pleased to support the open source community by making tRPC available.
+//
+// Copyright (C) 2023 THL A29 Limited, a Tencent company.
+// All rights reserved.
+//
+// If you have downloaded a copy of the tRPC source code from Tencent,
+// please note that tRPC source code is licensed under the  Apache 2.0 License,
+// A copy of the Apache 2.0 License is included in this file.
+//
+//
+
+package transport
+
+import (
+	"context"
+	"io"
+)
+
+// RoundTripOption configures a single RoundTrip or NewStream call, the
+// transport-level counterpart of client.Option.
+type RoundTripOption func(*RoundTripOptions)
+
+// RoundTripOptions carries the per-call knobs a ClientTransport or
+// ClientStreamTransport implementation consumes.
+type RoundTripOptions struct {
+	// InitialWindowSize is the initial flow-control window, in bytes,
+	// advertised when a stream is established. <= 0 means the transport's default.
+	// Pool does not read it.
+	InitialWindowSize int
+	// Network and Address identify the node the selector already picked for
+	// this call (e.g. "tcp", "10.0.0.1:8080"), the PoolKey a Pool-backed
+	// transport needs to find or dial the right pooled connection.
+	Network string
+	Address string
+}
+
+// WithInitialWindowSize sets RoundTripOptions.InitialWindowSize.
+func WithInitialWindowSize(size int) RoundTripOption {
+	return func(o *RoundTripOptions) { o.InitialWindowSize = size }
+}
+
+// WithTarget sets RoundTripOptions.Network/Address to the node the selector
+// picked for this call, so a Pool-backed transport can turn it into a
+// PoolKey without needing any other way to see the selected node.
+func WithTarget(network, address string) RoundTripOption {
+	return func(o *RoundTripOptions) { o.Network = network; o.Address = address }
+}
+
+// resolveRoundTripOptions applies opt in order over a zero-valued
+// RoundTripOptions, the shared helper every ClientTransport/
+// ClientStreamTransport implementation in this package uses to read them.
+func resolveRoundTripOptions(opt ...RoundTripOption) RoundTripOptions {
+	var ro RoundTripOptions
+	for _, o := range opt {
+		o(&ro)
+	}
+	return ro
+}
+
+// ClientStreamTransport is implemented by transports that, in addition to
+// the default one-shot RoundTrip, can open a long-lived, multi-message
+// stream for server-streaming/bidi-streaming RPCs. The returned io.ReadWriter
+// stays open across repeated SendMsg/RecvMsg calls until the stream ends.
+type ClientStreamTransport interface {
+	NewStream(ctx context.Context, opt ...RoundTripOption) (io.ReadWriter, error)
+}